@@ -1,79 +1,136 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type app struct {
-	cfg        controllerConfig
-	supervisor *supervisor
-	proxy      http.Handler
+	cfg           controllerConfig
+	supervisor    *supervisor
+	proxy         http.Handler
+	backendTarget *atomic.Pointer[url.URL]
+	inflight      *inflightTracker
+	totalServed   int64
+	auth          Auth
+	metrics       *metrics
+	shuttingDown  atomic.Bool
 }
 
 func newApp(cfg controllerConfig, sup *supervisor) (*app, error) {
-	proxy, err := newReverseProxy(cfg)
+	auth, err := newAuth(cfg.controlAuthConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &atomic.Pointer[url.URL]{}
+	proxy, err := newReverseProxy(cfg, target)
 	if err != nil {
 		return nil, err
 	}
 	return &app{
-		cfg:        cfg,
-		supervisor: sup,
-		proxy:      proxy,
+		cfg:           cfg,
+		supervisor:    sup,
+		proxy:         proxy,
+		backendTarget: target,
+		inflight:      newInflightTracker(),
+		auth:          auth,
+		metrics:       newMetrics(sup),
 	}, nil
 }
 
-func (a *app) Routes() *http.ServeMux {
+func (a *app) Routes() http.Handler {
 	mux := http.NewServeMux()
 	for _, route := range a.routeTable() {
 		handler := route.handler
-		if route.auth == authGatewayBearer {
-			handler = a.requireGatewayAuth(handler)
+		if route.auth == authControl {
+			handler = a.requireControl(handler)
 		}
 		mux.HandleFunc(route.pattern, handler)
 	}
 	mux.HandleFunc("/", a.handleProxy)
-	return mux
+	return withRequestLogging(mux)
+}
+
+// withRequestLogging generates or propagates an X-Request-ID, stashes it
+// in the request context for downstream handlers (notably the proxy
+// Director), and logs one structured record per request.
+func withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = generateRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDContextKey, requestID))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		logger.Info("http.request",
+			"component", "http",
+			"request_id", requestID,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote", r.RemoteAddr,
+		)
+	})
 }
 
 func (a *app) routeTable() []routeDef {
 	return []routeDef{
 		{pattern: "GET /health", auth: authNone, handler: a.handleHealth},
-		{pattern: "GET /gateway/status", auth: authGatewayBearer, handler: a.handleGatewayStatus},
-		{pattern: "POST /gateway/start", auth: authGatewayBearer, handler: a.handleGatewayStart},
-		{pattern: "POST /gateway/stop", auth: authGatewayBearer, handler: a.handleGatewayStop},
-		{pattern: "POST /gateway/restart", auth: authGatewayBearer, handler: a.handleGatewayRestart},
+		{pattern: "GET /gateway/status", auth: authControl, handler: a.handleGatewayStatus},
+		{pattern: "POST /gateway/start", auth: authControl, handler: a.handleGatewayStart},
+		{pattern: "POST /gateway/stop", auth: authControl, handler: a.handleGatewayStop},
+		{pattern: "POST /gateway/restart", auth: authControl, handler: a.handleGatewayRestart},
+		{pattern: "POST /gateway/reload", auth: authControl, handler: a.handleGatewayReload},
+		{pattern: "GET /gateway/logs", auth: authControl, handler: a.handleGatewayLogs},
+		{pattern: "GET /gateway/events", auth: authControl, handler: a.handleGatewayEvents},
+		{pattern: "GET /metrics", auth: authNone, handler: a.handleMetrics},
 	}
 }
 
-func (a *app) requireGatewayAuth(next http.HandlerFunc) http.HandlerFunc {
+func (a *app) requireControl(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		header := r.Header.Get("Authorization")
-		if !strings.HasPrefix(header, bearerPrefix) {
-			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
-			return
-		}
-		token := strings.TrimPrefix(header, bearerPrefix)
-		if token != a.cfg.expectedToken {
+		ctx, ok := a.auth.Authenticate(r)
+		if !ok {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 			return
 		}
-		next(w, r)
+		next(w, r.WithContext(ctx))
 	}
 }
 
 func (a *app) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	stats := a.supervisor.Stats()
 	writeJSON(w, http.StatusOK, map[string]any{
-		"status":   "ok",
-		"gateway":  stats.State,
-		"uptime":   stats.Uptime,
-		"restarts": stats.Restarts,
+		"status":        "ok",
+		"gateway":       stats.State,
+		"uptime":        stats.Uptime,
+		"restarts":      stats.Restarts,
+		"proxyInflight": a.inflight.Total(),
 	})
 }
 
 func (a *app) handleGatewayStatus(w http.ResponseWriter, _ *http.Request) {
-	writeJSON(w, http.StatusOK, a.supervisor.Stats())
+	writeJSON(w, http.StatusOK, statusResponse{
+		supervisorStats:  a.supervisor.Stats(),
+		ProxyInflight:    a.inflight.Total(),
+		ProxyTotalServed: atomic.LoadInt64(&a.totalServed),
+	})
 }
 
 func (a *app) handleGatewayStart(w http.ResponseWriter, _ *http.Request) {
@@ -112,11 +169,175 @@ func (a *app) handleGatewayRestart(w http.ResponseWriter, _ *http.Request) {
 }
 
 func (a *app) handleProxy(w http.ResponseWriter, r *http.Request) {
+	if a.shuttingDown.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Service Unavailable"})
+		return
+	}
+
 	if a.cfg.requireProxyToken && r.Header.Get(proxyTokenHeader) != a.cfg.expectedToken {
 		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 		return
 	}
 
 	r.Header.Del(proxyTokenHeader)
-	a.proxy.ServeHTTP(w, r)
+
+	exempt := a.cfg.longRunningPathRE != nil && a.cfg.longRunningPathRE.MatchString(r.URL.Path)
+	if !exempt && a.cfg.maxProxyInflight > 0 && a.inflight.Total() >= int64(a.cfg.maxProxyInflight) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Service Unavailable"})
+		return
+	}
+
+	// Attribute this request to whichever backend port it's actually about
+	// to be dispatched to, so a reload's drain check can tell the old
+	// backend apart from the new one instead of seeing one merged count.
+	port, err := portFromURL(a.backendTarget.Load())
+	if err != nil {
+		logger.Error("proxy.backend_target_invalid", "component", "proxy", "request_id", requestIDFromContext(r.Context()), "error", err.Error())
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "Bad Gateway"})
+		return
+	}
+	counter := a.inflight.counter(port)
+
+	atomic.AddInt64(counter, 1)
+	atomic.AddInt64(&a.totalServed, 1)
+	a.metrics.proxyInflight.Set(float64(a.inflight.Total()))
+	defer func() {
+		atomic.AddInt64(counter, -1)
+		a.metrics.proxyInflight.Set(float64(a.inflight.Total()))
+	}()
+
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	a.proxy.ServeHTTP(rec, r)
+	a.metrics.proxyRequestsTotal.WithLabelValues(strconv.Itoa(rec.status)).Inc()
+	a.metrics.proxyDuration.Observe(time.Since(start).Seconds())
+}
+
+func (a *app) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.metricsToken != "" {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, bearerPrefix)
+		if !strings.HasPrefix(header, bearerPrefix) || subtle.ConstantTimeCompare([]byte(token), []byte(a.cfg.metricsToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			return
+		}
+	}
+	a.metrics.handler.ServeHTTP(w, r)
+}
+
+func (a *app) handleGatewayReload(w http.ResponseWriter, _ *http.Request) {
+	if err := a.reloadGateway(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// reloadGateway spawns a second gateway process, waits for it to become
+// reachable, atomically swaps the reverse proxy target to it, drains
+// requests still in flight against the old backend, and then terminates
+// the old process. Shared by the /gateway/reload handler and the SIGHUP
+// handler in main.go.
+//
+// If the old backend hasn't gone idle by the time the drain window
+// elapses (a long-running request exempted from the concurrency cap is
+// the common case), the old process is left running rather than killed
+// out from under that request: promoteWhenDrained keeps polling in the
+// background and promotes as soon as it actually empties out.
+func (a *app) reloadGateway() error {
+	child, err := a.supervisor.SpawnPending()
+	if err != nil {
+		return err
+	}
+
+	if err := a.waitForReady(child.port); err != nil {
+		a.supervisor.AbortPending()
+		return errors.New("new gateway failed readiness probe: " + err.Error())
+	}
+
+	oldPort, err := portFromURL(a.backendTarget.Load())
+	if err != nil {
+		a.supervisor.AbortPending()
+		return errors.New("could not determine old backend port: " + err.Error())
+	}
+
+	a.backendTarget.Store(backendURL(a.cfg.backendHost, child.port))
+
+	if a.drainPort(oldPort, shutdownTimeout) {
+		return a.supervisor.PromotePending()
+	}
+
+	logger.Warn("gateway.reload_drain_incomplete", "component", "supervisor", "port", oldPort, "inflight", a.inflight.Load(oldPort))
+	go a.promoteWhenDrained(oldPort)
+	return fmt.Errorf("old backend on port %d still has requests in flight after %s; it keeps running until they finish and promotion completes in the background", oldPort, shutdownTimeout)
+}
+
+func (a *app) waitForReady(port int) error {
+	addr := net.JoinHostPort(a.cfg.backendHost, strconv.Itoa(port))
+	deadline := time.Now().Add(reloadReadyTimeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, reloadProbeInterval)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(reloadProbeInterval)
+	}
+	return errors.New("timed out waiting for " + addr)
+}
+
+// BeginShutdown stops handleProxy from accepting new proxied requests. It
+// must be called before (or at latest, concurrently with) drainInflight --
+// otherwise new requests keep arriving through the still-open listener for
+// the whole drain window and the in-flight count never reaches zero.
+func (a *app) BeginShutdown() {
+	a.shuttingDown.Store(true)
+}
+
+// drainInflight blocks until no proxied requests are in flight against any
+// backend, or timeout elapses, whichever comes first. Used for full-process
+// shutdown, where there's no "new" backend to confuse the count with.
+func (a *app) drainInflight(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for a.inflight.Total() > 0 && time.Now().Before(deadline) {
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+// drainPort blocks until no proxied requests are in flight against port, or
+// timeout elapses, whichever comes first, and reports whether it actually
+// reached zero.
+func (a *app) drainPort(port int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if a.inflight.Load(port) == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+// promoteWhenDrained is reloadGateway's fallback when the initial drain
+// window elapses with the old backend still serving requests: it keeps
+// polling rather than killing the old process out from under them, and
+// promotes as soon as the port actually goes idle. If it never does, the
+// old process is simply left running and the mismatch is logged for an
+// operator to investigate.
+func (a *app) promoteWhenDrained(port int) {
+	deadline := time.Now().Add(reloadDrainMaxWait)
+	for time.Now().Before(deadline) {
+		if a.inflight.Load(port) == 0 {
+			if err := a.supervisor.PromotePending(); err != nil {
+				logger.Error("gateway.reload_promote_failed", "component", "supervisor", "port", port, "error", err.Error())
+			} else {
+				logger.Info("gateway.reload_promoted", "component", "supervisor", "port", port)
+			}
+			return
+		}
+		time.Sleep(reloadProbeInterval)
+	}
+	logger.Error("gateway.reload_drain_timeout", "component", "supervisor", "port", port)
 }
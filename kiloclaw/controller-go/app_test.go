@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestControlRouteRequiresAuth guards against a regression where the
+// method-prefixed ServeMux patterns used throughout routeTable ("GET
+// /health", "POST /gateway/reload", ...) require Go 1.22+ to be parsed as
+// method+path matches. On an older toolchain they're silently treated as
+// literal path segments that never match, so every control route falls
+// through to the unauthenticated "/" proxy catch-all instead of 401ing.
+func TestControlRouteRequiresAuth(t *testing.T) {
+	cfg := controllerConfig{
+		backendHost:       defaultBackendHost,
+		backendPort:       defaultBackendPort,
+		controlAuthConfig: "static://token=test-token",
+	}
+	sup := newSupervisor(nil, cfg.backendPort)
+	a, err := newApp(cfg, sup)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/gateway/status", nil)
+	rec := httptest.NewRecorder()
+	a.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /gateway/status with no credentials returned %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+// TestHandleProxyRejectsDuringShutdown guards against a regression where
+// main.go's shutdown sequence called drainInflight before server.Shutdown
+// closed the listener: new proxied requests kept arriving and incrementing
+// the in-flight count throughout the drain window, so under sustained
+// traffic the drain always timed out. BeginShutdown must make handleProxy
+// reject new work immediately, independent of the listener actually closing.
+func TestHandleProxyRejectsDuringShutdown(t *testing.T) {
+	cfg := controllerConfig{
+		backendHost:       defaultBackendHost,
+		backendPort:       defaultBackendPort,
+		controlAuthConfig: "static://token=test-token",
+	}
+	sup := newSupervisor(nil, cfg.backendPort)
+	a, err := newApp(cfg, sup)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+
+	a.BeginShutdown()
+
+	req := httptest.NewRequest(http.MethodGet, "/some/proxied/path", nil)
+	rec := httptest.NewRecorder()
+	a.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("proxy request after BeginShutdown returned %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if a.inflight.Total() != 0 {
+		t.Fatalf("inflight count = %d, want 0 after a rejected request", a.inflight.Total())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewAuthStaticToken guards against a regression where newAuth parsed
+// KILOCLAW_CONTROL_AUTH with net/url, which only populates u.Query() when a
+// literal "?" precedes the key=value pair -- a "?" the documented
+// static://token=... format never has, so every static:// config failed
+// to parse with "static auth requires a token= parameter".
+func TestNewAuthStaticToken(t *testing.T) {
+	auth, err := newAuth("static://token=abc123")
+	if err != nil {
+		t.Fatalf("newAuth(%q): %v", "static://token=abc123", err)
+	}
+	if _, ok := auth.(*staticAuth); !ok {
+		t.Fatalf("newAuth returned %T, want *staticAuth", auth)
+	}
+}
+
+// TestNewAuthCertCA exercises the documented cert://ca=/path/to/ca.pem
+// format. It doesn't use that literal path since nothing is mounted there
+// in a test environment, but confirms the string parses into a ca path
+// that's actually handed to newCertAuth -- the failure is a file-read
+// error, not the "requires a ca= parameter" error the unfixed parser
+// produced for this exact documented format.
+func TestNewAuthCertCA(t *testing.T) {
+	_, err := newAuth("cert://ca=/path/to/ca.pem")
+	if err == nil || !strings.Contains(err.Error(), "reading cert auth CA") {
+		t.Fatalf("newAuth(%q) error = %v, want a CA file-read error", "cert://ca=/path/to/ca.pem", err)
+	}
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caPath, []byte(generateTestCAPEM(t)), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	auth, err := newAuth("cert://ca=" + caPath)
+	if err != nil {
+		t.Fatalf("newAuth with real CA file: %v", err)
+	}
+	if _, ok := auth.(*certAuth); !ok {
+		t.Fatalf("newAuth returned %T, want *certAuth", auth)
+	}
+}
+
+// TestNewAuthBasicFile confirms the basicfile:///path format, which never
+// relied on query parsing, is unaffected by the static/cert parsing fix.
+func TestNewAuthBasicFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "htpasswd")
+	if err := os.WriteFile(path, []byte("user:$2a$10$abcdefghijklmnopqrstuv\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	auth, err := newAuth("basicfile://" + path)
+	if err != nil {
+		t.Fatalf("newAuth: %v", err)
+	}
+	if _, ok := auth.(*basicFileAuth); !ok {
+		t.Fatalf("newAuth returned %T, want *basicFileAuth", auth)
+	}
+}
+
+// generateTestCAPEM returns a minimal self-signed certificate, PEM-encoded,
+// suitable only for exercising x509.CertPool.AppendCertsFromPEM in tests.
+func generateTestCAPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating test CA key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kiloclaw-test-ca"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating test CA cert: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -36,6 +37,36 @@ func loadConfigFromEnv() (controllerConfig, error) {
 
 	requireProxyToken := strings.EqualFold(strings.TrimSpace(os.Getenv("REQUIRE_PROXY_TOKEN")), "true")
 
+	controlAuthConfig := strings.TrimSpace(os.Getenv("KILOCLAW_CONTROL_AUTH"))
+	if controlAuthConfig == "" {
+		// Preserve the historical behavior: a single shared bearer token
+		// guards the control API unless an explicit auth backend is set.
+		controlAuthConfig = "static://token=" + token
+	}
+
+	maxProxyInflight := 0
+	if raw := strings.TrimSpace(os.Getenv("MAX_PROXY_INFLIGHT")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return controllerConfig{}, errors.New("MAX_PROXY_INFLIGHT must be a non-negative integer")
+		}
+		maxProxyInflight = parsed
+	}
+
+	var longRunningPathRE *regexp.Regexp
+	if raw := strings.TrimSpace(os.Getenv("LONG_RUNNING_PATH_RE")); raw != "" {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return controllerConfig{}, fmt.Errorf("LONG_RUNNING_PATH_RE must be a valid regexp: %w", err)
+		}
+		longRunningPathRE = re
+	}
+
+	metricsToken := strings.TrimSpace(os.Getenv("METRICS_TOKEN"))
+
+	tlsCertFile := strings.TrimSpace(os.Getenv("KILOCLAW_TLS_CERT_FILE"))
+	tlsKeyFile := strings.TrimSpace(os.Getenv("KILOCLAW_TLS_KEY_FILE"))
+
 	return controllerConfig{
 		port:              port,
 		expectedToken:     token,
@@ -43,5 +74,11 @@ func loadConfigFromEnv() (controllerConfig, error) {
 		backendHost:       defaultBackendHost,
 		backendPort:       defaultBackendPort,
 		gatewayArgs:       gatewayArgs,
+		controlAuthConfig: controlAuthConfig,
+		maxProxyInflight:  maxProxyInflight,
+		longRunningPathRE: longRunningPathRE,
+		metricsToken:      metricsToken,
+		tlsCertFile:       tlsCertFile,
+		tlsKeyFile:        tlsKeyFile,
 	}, nil
 }
@@ -0,0 +1,64 @@
+package main
+
+import "sync"
+
+// gatewayEvent is a supervisor state transition pushed to /gateway/events
+// subscribers whenever state, lastExit, or restarts change.
+type gatewayEvent struct {
+	State    supervisorState `json:"state"`
+	Restarts int             `json:"restarts"`
+	LastExit *lastExit       `json:"lastExit"`
+	At       string          `json:"at"`
+}
+
+// eventHub fans out gateway lifecycle events to any number of subscribers,
+// remembering the most recent event so newly connected clients see current
+// state immediately instead of waiting for the next transition.
+type eventHub struct {
+	mu   sync.Mutex
+	last *gatewayEvent
+	subs map[chan gatewayEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subs: make(map[chan gatewayEvent]struct{}),
+	}
+}
+
+func (h *eventHub) publish(event gatewayEvent) {
+	h.mu.Lock()
+	h.last = &event
+	subs := make([]chan gatewayEvent, 0, len(h.subs))
+	for ch := range h.subs {
+		subs = append(subs, ch)
+	}
+	h.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber; drop the event rather than block publishing.
+		}
+	}
+}
+
+func (h *eventHub) subscribe() (ch chan gatewayEvent, last *gatewayEvent, unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch = make(chan gatewayEvent, 64)
+	h.subs[ch] = struct{}{}
+	last = h.last
+
+	unsubscribe = func() {
+		h.mu.Lock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+		h.mu.Unlock()
+	}
+	return ch, last, unsubscribe
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// inflightTracker counts in-flight proxied requests per backend port. A
+// single process-wide counter can't tell a reload when the OLD backend has
+// actually gone idle, since a steady stream of new requests landing on the
+// freshly promoted backend keeps it above zero indefinitely; tracking per
+// port lets the drain check the one count that actually matters.
+type inflightTracker struct {
+	mu     sync.Mutex
+	counts map[int]*int64
+}
+
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{counts: make(map[int]*int64)}
+}
+
+func (t *inflightTracker) counter(port int) *int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.counts[port]
+	if !ok {
+		c = new(int64)
+		t.counts[port] = c
+	}
+	return c
+}
+
+// Load returns the current in-flight count for port, or 0 if no request has
+// ever been attributed to it.
+func (t *inflightTracker) Load(port int) int64 {
+	return atomic.LoadInt64(t.counter(port))
+}
+
+// Total returns the in-flight count summed across every port seen so far.
+func (t *inflightTracker) Total() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total int64
+	for _, c := range t.counts {
+		total += atomic.LoadInt64(c)
+	}
+	return total
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const requestIDContextKey contextKey = "kiloclaw-request-id"
+
+// logger is replaced wholesale by initLogger once KILOCLAW_LOG_LEVEL is
+// known; it defaults to info level so anything logged before that (there
+// shouldn't be much) still goes somewhere sane.
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+func initLogger(levelName string) {
+	var level slog.Level
+	switch strings.ToLower(strings.TrimSpace(levelName)) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		level = slog.LevelInfo
+	}
+	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// fatal logs a structured error record and exits, replacing the previous
+// log.Fatalf call sites.
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(requestIDContextKey).(string)
+	return v
+}
+
+func generateRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// logLine is a single line of child process output, tagged with the stream
+// it came from so subscribers can distinguish stdout from stderr.
+type logLine struct {
+	Stream string    `json:"stream"`
+	Text   string    `json:"text"`
+	At     time.Time `json:"at"`
+}
+
+// logHub fans out gateway stdout/stderr lines to any number of subscribers
+// and retains a bounded ring buffer so newly connected clients can replay
+// recent history before following the live stream.
+type logHub struct {
+	mu   sync.Mutex
+	size int
+	buf  []logLine
+	subs map[chan logLine]struct{}
+}
+
+func newLogHub(size int) *logHub {
+	return &logHub{
+		size: size,
+		subs: make(map[chan logLine]struct{}),
+	}
+}
+
+func (h *logHub) publish(line logLine) {
+	h.mu.Lock()
+	h.buf = append(h.buf, line)
+	if len(h.buf) > h.size {
+		h.buf = h.buf[len(h.buf)-h.size:]
+	}
+	subs := make([]chan logLine, 0, len(h.subs))
+	for ch := range h.subs {
+		subs = append(subs, ch)
+	}
+	h.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- line:
+		default:
+			// Slow subscriber; drop the line rather than block publishing.
+		}
+	}
+}
+
+// subscribe registers a new subscriber and returns a snapshot of the
+// retained history alongside the channel that will receive subsequent
+// lines, plus an unsubscribe func the caller must invoke when done.
+func (h *logHub) subscribe() (ch chan logLine, history []logLine, unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch = make(chan logLine, 256)
+	h.subs[ch] = struct{}{}
+	history = append([]logLine(nil), h.buf...)
+
+	unsubscribe = func() {
+		h.mu.Lock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+		h.mu.Unlock()
+	}
+	return ch, history, unsubscribe
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,19 +12,21 @@ import (
 )
 
 func main() {
+	initLogger(os.Getenv("KILOCLAW_LOG_LEVEL"))
+
 	cfg, err := loadConfigFromEnv()
 	if err != nil {
-		log.Fatalf("[controller-go] configuration error: %v", err)
+		fatal("controller.config_error", "component", "http", "error", err.Error())
 	}
 
-	sup := newSupervisor(cfg.gatewayArgs)
+	sup := newSupervisor(cfg.gatewayArgs, cfg.backendPort)
 	if _, err := sup.Start(); err != nil {
-		log.Fatalf("[controller-go] failed to start gateway: %v", err)
+		fatal("controller.gateway_start_failed", "component", "supervisor", "error", err.Error())
 	}
 
 	app, err := newApp(cfg, sup)
 	if err != nil {
-		log.Fatalf("[controller-go] failed to initialize app: %v", err)
+		fatal("controller.app_init_failed", "component", "http", "error", err.Error())
 	}
 
 	server := &http.Server{
@@ -32,27 +34,72 @@ func main() {
 		Handler: app.Routes(),
 	}
 
-	log.Printf("[controller-go] listening on %s requireProxyToken=%t", server.Addr, cfg.requireProxyToken)
+	// certAuth identifies callers by their TLS client certificate, which
+	// only ever reaches r.TLS if the listener itself is configured to
+	// request and verify one -- wire that up here rather than shipping a
+	// control-auth backend that 401s every request the moment it's chosen.
+	usesTLSClientAuth := false
+	if provider, ok := app.auth.(tlsClientCAPool); ok {
+		usesTLSClientAuth = true
+		if cfg.tlsCertFile == "" || cfg.tlsKeyFile == "" {
+			fatal("controller.tls_config_missing", "component", "http", "error", "KILOCLAW_CONTROL_AUTH is cert:// but KILOCLAW_TLS_CERT_FILE/KILOCLAW_TLS_KEY_FILE are not set")
+		}
+		server.TLSConfig = &tls.Config{
+			ClientCAs:  provider.ClientCAPool(),
+			ClientAuth: tls.RequireAndVerifyClientCert,
+		}
+	}
+
+	logger.Info("controller.listening", "component", "http", "addr", server.Addr, "require_proxy_token", cfg.requireProxyToken, "tls", usesTLSClientAuth)
+
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+
+	go func() {
+		for range reloadSignals {
+			logger.Info("controller.sighup_received", "component", "supervisor")
+			if ra, ok := app.auth.(reloadableAuth); ok {
+				if err := ra.Reload(); err != nil {
+					logger.Error("controller.auth_reload_failed", "component", "http", "error", err.Error())
+				}
+			}
+			if err := app.reloadGateway(); err != nil {
+				logger.Error("gateway.reload_failed", "component", "supervisor", "error", err.Error())
+			}
+		}
+	}()
 
 	stopSignals := make(chan os.Signal, 1)
 	signal.Notify(stopSignals, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
 		sig := <-stopSignals
-		log.Printf("[controller-go] received %s, shutting down", sig.String())
+		logger.Info("controller.shutting_down", "component", "http", "signal", sig.String())
+
+		// Stop accepting new proxy work before waiting for the existing
+		// work to drain -- otherwise traffic through the still-open
+		// listener keeps the in-flight count from ever reaching zero.
+		app.BeginShutdown()
+		app.drainInflight(shutdownTimeout)
 
 		if err := sup.Shutdown(sig); err != nil {
-			log.Printf("[controller-go] supervisor shutdown error: %v", err)
+			logger.Error("controller.supervisor_shutdown_error", "component", "supervisor", "error", err.Error())
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
 		defer cancel()
 		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("[controller-go] server shutdown error: %v", err)
+			logger.Error("controller.server_shutdown_error", "component", "http", "error", err.Error())
 		}
 	}()
 
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("[controller-go] server error: %v", err)
+	var serveErr error
+	if usesTLSClientAuth {
+		serveErr = server.ListenAndServeTLS(cfg.tlsCertFile, cfg.tlsKeyFile)
+	} else {
+		serveErr = server.ListenAndServe()
+	}
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		fatal("controller.server_error", "component", "http", "error", serveErr.Error())
 	}
 }
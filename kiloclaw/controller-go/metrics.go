@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// allSupervisorStates lists every supervisorState value so the gateway
+// state gauge can be reset across all of them on every transition.
+var allSupervisorStates = []supervisorState{
+	stateStopped, stateStarting, stateRunning, stateStopping, stateCrashed, stateShuttingDown,
+}
+
+// metrics holds the Prometheus collectors exposed on /metrics. It owns its
+// own registry rather than using the global default so multiple apps (as
+// in tests) don't collide on collector registration.
+type metrics struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+
+	gatewayState    *prometheus.GaugeVec
+	gatewayRestarts prometheus.Counter
+	gatewayLastExit prometheus.Gauge
+
+	proxyRequestsTotal *prometheus.CounterVec
+	proxyDuration      prometheus.Histogram
+	proxyInflight      prometheus.Gauge
+
+	lastRestarts int
+}
+
+func newMetrics(sup *supervisor) *metrics {
+	m := &metrics{
+		gatewayState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kiloclaw_gateway_state",
+			Help: "1 for the supervisor's current state, 0 for all others.",
+		}, []string{"state"}),
+		gatewayRestarts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kiloclaw_gateway_restarts_total",
+			Help: "Total number of times the gateway process has been restarted after a crash.",
+		}),
+		gatewayLastExit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kiloclaw_gateway_last_exit_code",
+			Help: "Exit code of the most recently exited gateway process.",
+		}),
+		proxyRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kiloclaw_proxy_requests_total",
+			Help: "Total number of proxied requests by response status code.",
+		}, []string{"code"}),
+		proxyDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "kiloclaw_proxy_request_duration_seconds",
+			Help:    "Latency of proxied requests in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		proxyInflight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kiloclaw_proxy_inflight",
+			Help: "Number of proxied requests currently in flight.",
+		}),
+	}
+
+	gatewayUptime := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "kiloclaw_gateway_uptime_seconds",
+		Help: "Seconds since the current gateway process started.",
+	}, func() float64 {
+		return float64(sup.Stats().Uptime)
+	})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		m.gatewayState,
+		m.gatewayRestarts,
+		m.gatewayLastExit,
+		gatewayUptime,
+		m.proxyRequestsTotal,
+		m.proxyDuration,
+		m.proxyInflight,
+	)
+	m.registry = reg
+	m.handler = promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+
+	m.watchEvents(sup.eventHub)
+	return m
+}
+
+// watchEvents subscribes to the supervisor's event hub for the lifetime of
+// the process and keeps the gateway gauges in sync with state transitions.
+func (m *metrics) watchEvents(hub *eventHub) {
+	ch, last, _ := hub.subscribe()
+	if last != nil {
+		m.applyEvent(*last)
+	}
+	go func() {
+		for event := range ch {
+			m.applyEvent(event)
+		}
+	}()
+}
+
+func (m *metrics) applyEvent(event gatewayEvent) {
+	for _, state := range allSupervisorStates {
+		value := 0.0
+		if state == event.State {
+			value = 1
+		}
+		m.gatewayState.WithLabelValues(string(state)).Set(value)
+	}
+
+	if delta := event.Restarts - m.lastRestarts; delta > 0 {
+		m.gatewayRestarts.Add(float64(delta))
+	}
+	m.lastRestarts = event.Restarts
+
+	if event.LastExit != nil && event.LastExit.Code != nil {
+		m.gatewayLastExit.Set(float64(*event.LastExit.Code))
+	}
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code written
+// by httputil.ReverseProxy, which never exposes it directly.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack forwards to the underlying ResponseWriter's http.Hijacker, if it
+// has one. Without this, wrapping every response in a statusRecorder (as
+// withRequestLogging does) breaks the WebSocket upgrades in ws.go: gorilla
+// type-asserts the ResponseWriter it's given to http.Hijacker, and an
+// embedded interface field doesn't satisfy that assertion on its own.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
@@ -2,27 +2,48 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
+	"sync/atomic"
 )
 
-func newReverseProxy(cfg controllerConfig) (*httputil.ReverseProxy, error) {
-	target, err := url.Parse(fmt.Sprintf("http://%s:%d", cfg.backendHost, cfg.backendPort))
-	if err != nil {
-		return nil, err
-	}
+// newReverseProxy builds a reverse proxy whose backend target is read from
+// an atomic pointer on every request, so reloadGateway can swap it to a
+// freshly promoted gateway without dropping in-flight connections.
+func newReverseProxy(cfg controllerConfig, target *atomic.Pointer[url.URL]) (*httputil.ReverseProxy, error) {
+	target.Store(backendURL(cfg.backendHost, cfg.backendPort))
 
-	proxy := httputil.NewSingleHostReverseProxy(target)
-	originalDirector := proxy.Director
-	proxy.Director = func(req *http.Request) {
-		originalDirector(req)
-		req.Header.Del(proxyTokenHeader)
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			t := target.Load()
+			req.URL.Scheme = t.Scheme
+			req.URL.Host = t.Host
+			req.Header.Del(proxyTokenHeader)
+			if id := requestIDFromContext(req.Context()); id != "" {
+				req.Header.Set("X-Request-ID", id)
+			}
+		},
 	}
-	proxy.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, err error) {
-		log.Printf("[controller-go] proxy error: %v", err)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		logger.Error("proxy.error", "component", "proxy", "request_id", requestIDFromContext(r.Context()), "error", err.Error())
 		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "Bad Gateway"})
 	}
 	return proxy, nil
 }
+
+func backendURL(host string, port int) *url.URL {
+	return &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%d", host, port)}
+}
+
+// portFromURL extracts the numeric port a backend target URL points at, so
+// callers can key in-flight counts and drain checks by port.
+func portFromURL(u *url.URL) (int, error) {
+	_, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(portStr)
+}
@@ -1,23 +1,40 @@
 package main
 
 import (
+	"bufio"
 	"errors"
-	"log"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 )
 
+// childProcess is one spawned "openclaw gateway" instance. During a normal
+// lifecycle the supervisor tracks a single current child; during a reload
+// it briefly tracks both a current and a pending child at once, each
+// listening on its own port so the old one can keep serving traffic while
+// the new one passes its readiness probe.
+type childProcess struct {
+	cmd       *exec.Cmd
+	port      int
+	startedAt time.Time
+	exitWait  chan struct{}
+}
+
 type supervisor struct {
 	mu sync.Mutex
 
 	gatewayArgs []string
 	state       supervisorState
-	cmd         *exec.Cmd
-	startedAt   time.Time
-	exitWait    chan struct{}
+	current     *childProcess
+	pending     *childProcess
+
+	primaryPort int
+	altPort     int
 
 	autoRestart   bool
 	stopRequested bool
@@ -27,14 +44,21 @@ type supervisor struct {
 	restartTimer *time.Timer
 	restarts     int
 	lastExit     *lastExit
+
+	logHub   *logHub
+	eventHub *eventHub
 }
 
-func newSupervisor(gatewayArgs []string) *supervisor {
+func newSupervisor(gatewayArgs []string, backendPort int) *supervisor {
 	return &supervisor{
 		gatewayArgs: gatewayArgs,
 		state:       stateStopped,
 		autoRestart: true,
 		backoff:     backoffInitial,
+		primaryPort: backendPort,
+		altPort:     backendPort + reloadPortOffset,
+		logHub:      newLogHub(logBufferSize),
+		eventHub:    newEventHub(),
 	}
 }
 
@@ -66,20 +90,27 @@ func (s *supervisor) Stop() (bool, error) {
 	s.stopRequested = true
 	s.cancelRestartLocked()
 
-	cmd := s.cmd
-	waitCh := s.exitWait
-	if cmd == nil {
+	pending := s.pending
+	s.pending = nil
+
+	current := s.current
+	if current == nil {
 		s.state = stateStopped
+		s.publishEventLocked()
 		s.mu.Unlock()
+		stopChild(pending)
 		return false, nil
 	}
 	s.state = stateStopping
+	logger.Info("gateway.stopping", "component", "supervisor", "state", string(stateStopping), "pid", childPid(current))
+	s.publishEventLocked()
 	s.mu.Unlock()
 
-	if err := signalProcess(cmd, syscall.SIGTERM); err != nil {
+	stopChild(pending)
+	if err := signalProcess(current.cmd, syscall.SIGTERM); err != nil {
 		return false, err
 	}
-	waitForExit(waitCh, cmd)
+	waitForExit(current.exitWait, current.cmd)
 	return true, nil
 }
 
@@ -97,34 +128,101 @@ func (s *supervisor) Shutdown(sig os.Signal) error {
 	s.autoRestart = false
 	s.stopRequested = true
 	s.cancelRestartLocked()
+	logger.Info("gateway.shutting_down", "component", "supervisor", "state", string(stateShuttingDown), "signal", sig.String())
+	s.publishEventLocked()
 
-	cmd := s.cmd
-	waitCh := s.exitWait
+	pending := s.pending
+	s.pending = nil
+	current := s.current
 	s.mu.Unlock()
 
-	if cmd == nil {
+	stopChild(pending)
+
+	if current == nil {
 		return nil
 	}
-	if err := signalProcess(cmd, sig); err != nil {
+	if err := signalProcess(current.cmd, sig); err != nil {
 		return err
 	}
-	waitForExit(waitCh, cmd)
+	waitForExit(current.exitWait, current.cmd)
 	return nil
 }
 
-func (s *supervisor) Stats() supervisorStats {
+// SpawnPending starts a second gateway process alongside the current one,
+// listening on whichever of primaryPort/altPort the current child isn't
+// using. The caller is expected to probe it for readiness and then call
+// PromotePending or AbortPending.
+func (s *supervisor) SpawnPending() (*childProcess, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var pid *int
-	if s.cmd != nil && s.cmd.Process != nil {
-		v := s.cmd.Process.Pid
-		pid = &v
+	if s.shuttingDown {
+		return nil, errors.New("gateway is shutting down")
+	}
+	if s.current == nil {
+		return nil, errors.New("gateway is not running")
 	}
+	if s.pending != nil {
+		return nil, errors.New("reload already in progress")
+	}
+
+	port := s.altPort
+	if s.current.port == s.altPort {
+		port = s.primaryPort
+	}
+
+	child, err := s.spawnChildLocked(port)
+	if err != nil {
+		return nil, err
+	}
+	s.pending = child
+	return child, nil
+}
 
+// PromotePending makes the pending child the current one and terminates
+// the previous current. Callers should drain in-flight requests against
+// the old backend before calling this so the old process isn't killed out
+// from under a request that's still proxying to it.
+func (s *supervisor) PromotePending() error {
+	s.mu.Lock()
+	pending := s.pending
+	if pending == nil {
+		s.mu.Unlock()
+		return errors.New("no pending gateway to promote")
+	}
+	old := s.current
+	s.current = pending
+	s.pending = nil
+	s.publishEventLocked()
+	s.mu.Unlock()
+
+	stopChild(old)
+	return nil
+}
+
+// AbortPending terminates the pending child without touching current,
+// used when the new process fails its readiness probe.
+func (s *supervisor) AbortPending() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	stopChild(pending)
+}
+
+func (s *supervisor) Stats() supervisorStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pid *int
 	var uptime int64
-	if !s.startedAt.IsZero() {
-		uptime = int64(time.Since(s.startedAt).Seconds())
+	if s.current != nil {
+		if s.current.cmd.Process != nil {
+			v := s.current.cmd.Process.Pid
+			pid = &v
+		}
+		uptime = int64(time.Since(s.current.startedAt).Seconds())
 	}
 
 	return supervisorStats{
@@ -137,31 +235,90 @@ func (s *supervisor) Stats() supervisorStats {
 }
 
 func (s *supervisor) startLocked() error {
-	args := append([]string{"gateway"}, s.gatewayArgs...)
-
-	cmd := exec.Command("openclaw", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
 	s.state = stateStarting
-	if err := cmd.Start(); err != nil {
+	logger.Info("gateway.starting", "component", "supervisor", "state", string(stateStarting))
+
+	child, err := s.spawnChildLocked(s.primaryPort)
+	if err != nil {
 		s.state = stateStopped
 		return err
 	}
 
-	s.cmd = cmd
-	s.startedAt = time.Now()
+	s.current = child
 	s.state = stateRunning
-
-	exitCh := make(chan struct{})
-	s.exitWait = exitCh
-	go s.waitForProcess(cmd, s.startedAt, exitCh)
+	logger.Info("gateway.running", "component", "supervisor", "state", string(stateRunning), "pid", childPid(child), "port", child.port)
+	s.publishEventLocked()
 	return nil
 }
 
-func (s *supervisor) waitForProcess(cmd *exec.Cmd, startedAt time.Time, exitCh chan struct{}) {
-	err := cmd.Wait()
-	close(exitCh)
+// spawnChildLocked execs a gateway instance bound to port, wiring its
+// output into logHub and scheduling the goroutine that waits for its
+// exit. Callers must hold s.mu.
+func (s *supervisor) spawnChildLocked(port int) (*childProcess, error) {
+	// The port flag is appended after gatewayArgs so it always wins over
+	// any --port the operator may have baked into KILOCLAW_GATEWAY_ARGS.
+	args := append(append([]string{"gateway"}, s.gatewayArgs...), "--port", strconv.Itoa(port))
+
+	cmd := exec.Command("openclaw", args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	child := &childProcess{
+		cmd:       cmd,
+		port:      port,
+		startedAt: time.Now(),
+		exitWait:  make(chan struct{}),
+	}
+
+	go s.pumpOutput(stdout, "stdout")
+	go s.pumpOutput(stderr, "stderr")
+	go s.waitForProcess(child)
+	return child, nil
+}
+
+// pumpOutput replaces the previous direct os.Stdout/os.Stderr wiring: it
+// echoes each line locally for operators tailing the controller process
+// and fans it out to logHub so /gateway/logs subscribers see it too.
+func (s *supervisor) pumpOutput(r io.Reader, stream string) {
+	out := os.Stdout
+	if stream == "stderr" {
+		out = os.Stderr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(out, line)
+		s.logHub.publish(logLine{Stream: stream, Text: line, At: time.Now().UTC()})
+	}
+}
+
+// publishEventLocked notifies eventHub subscribers of the current state,
+// restart count, and last exit. Callers must hold s.mu.
+func (s *supervisor) publishEventLocked() {
+	s.eventHub.publish(gatewayEvent{
+		State:    s.state,
+		Restarts: s.restarts,
+		LastExit: s.lastExit,
+		At:       time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *supervisor) waitForProcess(child *childProcess) {
+	err := child.cmd.Wait()
+	close(child.exitWait)
 
 	codePtr, signalName := exitDetails(err)
 	finishedAt := time.Now()
@@ -169,11 +326,19 @@ func (s *supervisor) waitForProcess(cmd *exec.Cmd, startedAt time.Time, exitCh c
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.cmd != cmd {
+	switch {
+	case s.current == child:
+		s.current = nil
+	case s.pending == child:
+		// The pending gateway crashed before it could be promoted; the
+		// reload caller's readiness probe will time out and report it.
+		s.pending = nil
+		return
+	default:
 		return
 	}
 
-	if time.Since(startedAt) >= healthyThreshold {
+	if time.Since(child.startedAt) >= healthyThreshold {
 		s.backoff = backoffInitial
 	}
 
@@ -182,22 +347,25 @@ func (s *supervisor) waitForProcess(cmd *exec.Cmd, startedAt time.Time, exitCh c
 		Signal: signalName,
 		At:     finishedAt.UTC().Format(time.RFC3339),
 	}
-	s.cmd = nil
-	s.startedAt = time.Time{}
-	s.exitWait = nil
 
 	if s.shuttingDown {
 		s.state = stateShuttingDown
+		logger.Info("gateway.shutting_down", "component", "supervisor", "state", string(stateShuttingDown))
+		s.publishEventLocked()
 		return
 	}
 	if s.stopRequested || !s.autoRestart {
 		s.state = stateStopped
 		s.stopRequested = false
+		logger.Info("gateway.stopped", "component", "supervisor", "state", string(stateStopped), "exit_code", exitCodeValue(codePtr), "signal", signalName)
+		s.publishEventLocked()
 		return
 	}
 
 	s.state = stateCrashed
 	s.restarts++
+	logger.Warn("gateway.crashed", "component", "supervisor", "state", string(stateCrashed), "exit_code", exitCodeValue(codePtr), "signal", signalName, "restarts", s.restarts)
+	s.publishEventLocked()
 	delay := s.backoff
 	s.backoff = minDuration(backoffMax, s.backoff*backoffMultiplier)
 	s.restartTimer = time.AfterFunc(delay, func() {
@@ -210,13 +378,14 @@ func (s *supervisor) restartAfterBackoff() {
 	defer s.mu.Unlock()
 
 	s.restartTimer = nil
-	if s.shuttingDown || !s.autoRestart || s.stopRequested || s.cmd != nil {
+	if s.shuttingDown || !s.autoRestart || s.stopRequested || s.current != nil {
 		return
 	}
 
 	if err := s.startLocked(); err != nil {
-		log.Printf("[controller-go] restart spawn failed: %v", err)
+		logger.Error("gateway.restart_failed", "component", "supervisor", "error", err.Error())
 		s.state = stateCrashed
+		s.publishEventLocked()
 		delay := s.backoff
 		s.backoff = minDuration(backoffMax, s.backoff*backoffMultiplier)
 		s.restartTimer = time.AfterFunc(delay, func() {
@@ -232,6 +401,14 @@ func (s *supervisor) cancelRestartLocked() {
 	}
 }
 
+func stopChild(child *childProcess) {
+	if child == nil {
+		return
+	}
+	_ = signalProcess(child.cmd, syscall.SIGTERM)
+	waitForExit(child.exitWait, child.cmd)
+}
+
 func waitForExit(waitCh chan struct{}, cmd *exec.Cmd) {
 	if waitCh != nil {
 		select {
@@ -280,6 +457,24 @@ func exitDetails(err error) (*int, string) {
 	return nil, ""
 }
 
+// childPid returns the child's pid for logging, or 0 if it hasn't started.
+func childPid(child *childProcess) int {
+	if child == nil || child.cmd.Process == nil {
+		return 0
+	}
+	return child.cmd.Process.Pid
+}
+
+// exitCodeValue unwraps an exit code pointer for structured logging,
+// returning nil (omitted from the JSON record) when the process was
+// killed by a signal instead of exiting normally.
+func exitCodeValue(codePtr *int) any {
+	if codePtr == nil {
+		return nil
+	}
+	return *codePtr
+}
+
 func minDuration(a, b time.Duration) time.Duration {
 	if a < b {
 		return a
@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"regexp"
 	"time"
 )
 
@@ -17,6 +18,11 @@ const (
 	healthyThreshold      = 30 * time.Second
 	shutdownTimeout       = 10 * time.Second
 	serverShutdownTimeout = 10 * time.Second
+	logBufferSize         = 1000
+	reloadPortOffset      = 1000
+	reloadReadyTimeout    = 10 * time.Second
+	reloadProbeInterval   = 100 * time.Millisecond
+	reloadDrainMaxWait    = 5 * time.Minute
 )
 
 type supervisorState string
@@ -37,6 +43,12 @@ type controllerConfig struct {
 	backendHost       string
 	backendPort       int
 	gatewayArgs       []string
+	controlAuthConfig string
+	maxProxyInflight  int
+	longRunningPathRE *regexp.Regexp
+	metricsToken      string
+	tlsCertFile       string
+	tlsKeyFile        string
 }
 
 type lastExit struct {
@@ -53,11 +65,19 @@ type supervisorStats struct {
 	LastExit *lastExit       `json:"lastExit"`
 }
 
+// statusResponse is what /gateway/status returns: supervisor stats plus
+// the proxy's in-flight gauge and lifetime request count.
+type statusResponse struct {
+	supervisorStats
+	ProxyInflight    int64 `json:"proxyInflight"`
+	ProxyTotalServed int64 `json:"proxyTotalServed"`
+}
+
 type authMode uint8
 
 const (
 	authNone authMode = iota
-	authGatewayBearer
+	authControl
 )
 
 type routeDef struct {
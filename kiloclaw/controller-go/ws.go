@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The controller is typically fronted by internal tooling/dashboards
+	// rather than arbitrary browser origins, so origin checking is left to
+	// whatever sits in front of it.
+	CheckOrigin: func(_ *http.Request) bool { return true },
+}
+
+// handleGatewayLogs streams the child openclaw process's stdout/stderr,
+// replaying recent history from the ring buffer before following live.
+func (a *app) handleGatewayLogs(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("ws.log_stream_upgrade_failed", "component", "http", "request_id", requestIDFromContext(r.Context()), "error", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go wsReadPump(conn, done)
+
+	ch, history, unsubscribe := a.supervisor.logHub.subscribe()
+	defer unsubscribe()
+
+	for _, line := range history {
+		if err := writeWSJSON(conn, line); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case line, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := writeWSJSON(conn, line); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := writeWSPing(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleGatewayEvents streams supervisor state transitions and restart/exit
+// metadata, sending the current state immediately on connect.
+func (a *app) handleGatewayEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("ws.event_stream_upgrade_failed", "component", "http", "request_id", requestIDFromContext(r.Context()), "error", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go wsReadPump(conn, done)
+
+	ch, last, unsubscribe := a.supervisor.eventHub.subscribe()
+	defer unsubscribe()
+
+	if last != nil {
+		if err := writeWSJSON(conn, last); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := writeWSJSON(conn, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := writeWSPing(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsReadPump drains and discards client frames so the gorilla/websocket
+// library can process control frames (close, ping/pong), and closes done
+// once the client disconnects or the connection errors. Every handler that
+// writes to a connection must run this in its own goroutine: these streams
+// only ever write, so without a read pump a client-initiated clean close is
+// never observed and the handler goroutine (plus its logHub/eventHub
+// subscription) leaks for the rest of the process's life.
+func wsReadPump(conn *websocket.Conn, done chan<- struct{}) {
+	defer close(done)
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func writeWSJSON(conn *websocket.Conn, payload any) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(payload)
+}
+
+func writeWSPing(conn *websocket.Conn) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteMessage(websocket.PingMessage, nil)
+}
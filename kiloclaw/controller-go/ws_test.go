@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestGatewayEventsWebSocketUpgrade guards against a regression where
+// wrapping every response in statusRecorder (withRequestLogging) broke the
+// WebSocket upgrade in handleGatewayEvents: gorilla/websocket type-asserts
+// the ResponseWriter it's given to http.Hijacker, which an embedded
+// interface field doesn't satisfy without its own Hijack method.
+func TestGatewayEventsWebSocketUpgrade(t *testing.T) {
+	cfg := controllerConfig{
+		backendHost:       defaultBackendHost,
+		backendPort:       defaultBackendPort,
+		controlAuthConfig: "static://token=test-token",
+	}
+	sup := newSupervisor(nil, cfg.backendPort)
+	a, err := newApp(cfg, sup)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+
+	srv := httptest.NewServer(a.Routes())
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/gateway/events"
+	header := http.Header{"Authorization": []string{"Bearer test-token"}}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial /gateway/events: %v (status %d)", err, status)
+	}
+	defer conn.Close()
+}